@@ -0,0 +1,191 @@
+package main
+
+import (
+	"encoding/csv"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"fyne.io/fyne/v2/data/binding"
+	"github.com/cahlchang/voice-pitch-monitor/wavsource"
+)
+
+const (
+	fileSampleRate = 48000.0
+	fileFrameSize  = 2048
+	fileHopSize    = 512
+)
+
+// csvRow is one analyzed frame from a loaded WAV file, in the shape
+// exported by filePlayback.exportCSV.
+type csvRow struct {
+	offset time.Duration
+	hz     float64
+	note   string
+	cents  float64
+	rms    float64
+}
+
+// filePlayback drives pitch analysis over a decoded WAV file at real-time
+// pace, pushing the same PitchBar/freqText/noteText/statusText updates
+// startStream does, and recording a CSV-exportable row per frame.
+type filePlayback struct {
+	mu           sync.Mutex
+	paused       bool
+	rows         []csvRow
+	stop         chan struct{}
+	producer     *wavsource.FrameProducer
+	duration     time.Duration
+	virtualStart time.Time
+	hist         *PitchHistory
+}
+
+// startFilePlayback decodes path and plays it back through the analysis
+// pipeline. The returned stop func halts playback; it's handed to the
+// same audioRunner a live device's stop func would be, so loading a file
+// and selecting a mic are mutually exclusive.
+func startFilePlayback(path string, ref *referenceState, bar *PitchBar, hist *PitchHistory, freqText, noteText, statusText binding.String, progress binding.Float) (*filePlayback, func(), error) {
+	wav, err := wavsource.Load(path, fileSampleRate)
+	if err != nil {
+		return nil, nil, fmt.Errorf("load wav: %w", err)
+	}
+
+	fp := &filePlayback{
+		stop:         make(chan struct{}),
+		producer:     wavsource.NewFrameProducer(wav.Data, fileFrameSize, fileHopSize),
+		duration:     wav.Duration(),
+		virtualStart: time.Now(),
+		hist:         hist,
+	}
+	hopDuration := time.Duration(fileHopSize / fileSampleRate * float64(time.Second))
+
+	// This goroutine turns the ticker-paced producer into the same
+	// analysisFrame feed startStream's device callback produces, so both
+	// sources run through the one shared runAnalysisLoop below instead of
+	// each hand-rolling a process/applyResult/hist.Push loop.
+	framesCh := make(chan analysisFrame)
+	go func() {
+		defer close(framesCh)
+		ticker := time.NewTicker(hopDuration)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-fp.stop:
+				return
+			case <-ticker.C:
+				if fp.isPaused() {
+					continue
+				}
+				buf, offset, ok := fp.producer.Next(fileSampleRate)
+				if !ok {
+					_ = statusText.Set("Playback finished")
+					return
+				}
+				select {
+				case framesCh <- analysisFrame{buf: buf, now: fp.virtualStart.Add(offset)}:
+				case <-fp.stop:
+					return
+				}
+			}
+		}
+	}()
+
+	go runAnalysisLoop(fp.stop, framesCh, fileSampleRate, ref, func() bool { return false }, bar, hist, freqText, noteText, statusText, func(res analysisResult, now time.Time) {
+		offset := now.Sub(fp.virtualStart)
+		fp.addRow(csvRow{
+			offset: offset,
+			hz:     res.freq,
+			note:   res.note,
+			cents:  res.deltaCents,
+			rms:    res.rms,
+		})
+		if progress != nil {
+			_ = progress.Set(offset.Seconds())
+		}
+	})
+
+	return fp, func() { close(fp.stop) }, nil
+}
+
+func (fp *filePlayback) isPaused() bool {
+	fp.mu.Lock()
+	defer fp.mu.Unlock()
+	return fp.paused
+}
+
+func (fp *filePlayback) togglePause() {
+	fp.mu.Lock()
+	defer fp.mu.Unlock()
+	fp.paused = !fp.paused
+}
+
+// Seek jumps playback to offset from the start of the file, for a scrub
+// slider to drive. It also drops any already-recorded rows/history points
+// at or after offset: without that, scrubbing backward and replaying
+// would re-push frames with an earlier timestamp than ones already
+// recorded, breaking both PitchHistory's monotonic-time assumption and
+// exportCSV's row ordering.
+func (fp *filePlayback) Seek(offset time.Duration) {
+	fp.producer.Seek(offset, fileSampleRate)
+	fp.truncateRowsAt(offset)
+	if fp.hist != nil {
+		fp.hist.TruncateAt(fp.virtualStart.Add(offset))
+	}
+}
+
+func (fp *filePlayback) truncateRowsAt(offset time.Duration) {
+	fp.mu.Lock()
+	defer fp.mu.Unlock()
+	i := 0
+	for i < len(fp.rows) && fp.rows[i].offset < offset {
+		i++
+	}
+	fp.rows = fp.rows[:i]
+}
+
+// Duration reports the total length of the loaded file, so a scrub
+// control knows its range.
+func (fp *filePlayback) Duration() time.Duration {
+	return fp.duration
+}
+
+func (fp *filePlayback) addRow(row csvRow) {
+	fp.mu.Lock()
+	defer fp.mu.Unlock()
+	fp.rows = append(fp.rows, row)
+}
+
+// exportCSV writes every frame analyzed so far as (timestamp, hz, note,
+// cents-vs-ref, rms) rows.
+func (fp *filePlayback) exportCSV(path string) error {
+	fp.mu.Lock()
+	rows := make([]csvRow, len(fp.rows))
+	copy(rows, fp.rows)
+	fp.mu.Unlock()
+
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	w := csv.NewWriter(f)
+	defer w.Flush()
+	if err := w.Write([]string{"timestamp_s", "hz", "note", "cents_vs_ref", "rms"}); err != nil {
+		return err
+	}
+	for _, r := range rows {
+		record := []string{
+			fmt.Sprintf("%.3f", r.offset.Seconds()),
+			fmt.Sprintf("%.2f", r.hz),
+			r.note,
+			fmt.Sprintf("%.1f", r.cents),
+			fmt.Sprintf("%.4f", r.rms),
+		}
+		if err := w.Write(record); err != nil {
+			return err
+		}
+	}
+	return w.Error()
+}
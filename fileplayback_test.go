@@ -0,0 +1,41 @@
+package main
+
+import (
+	"os"
+	"testing"
+)
+
+func TestFilePlaybackExportCSV(t *testing.T) {
+	fp := &filePlayback{stop: make(chan struct{})}
+	fp.addRow(csvRow{hz: 220.0, note: "A3", cents: 0, rms: 0.1})
+	fp.addRow(csvRow{hz: 0, note: "--", cents: 0, rms: 0})
+
+	tmp, err := os.CreateTemp(t.TempDir(), "export-*.csv")
+	if err != nil {
+		t.Fatalf("create temp file: %v", err)
+	}
+	path := tmp.Name()
+	tmp.Close()
+
+	if err := fp.exportCSV(path); err != nil {
+		t.Fatalf("exportCSV: %v", err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("read export: %v", err)
+	}
+	if len(data) == 0 {
+		t.Fatalf("expected non-empty CSV output")
+	}
+}
+
+func TestFilePlaybackTogglePause(t *testing.T) {
+	fp := &filePlayback{stop: make(chan struct{})}
+	if fp.isPaused() {
+		t.Fatalf("expected not paused initially")
+	}
+	fp.togglePause()
+	if !fp.isPaused() {
+		t.Fatalf("expected paused after toggle")
+	}
+}
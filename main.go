@@ -7,9 +7,11 @@ import (
 	"math"
 	"os"
 	"os/signal"
+	"path/filepath"
 	"reflect"
 	"runtime"
 	"sync"
+	"sync/atomic"
 	"syscall"
 	"time"
 	"unsafe"
@@ -19,8 +21,11 @@ import (
 	"fyne.io/fyne/v2/canvas"
 	"fyne.io/fyne/v2/container"
 	"fyne.io/fyne/v2/data/binding"
+	"fyne.io/fyne/v2/dialog"
 	"fyne.io/fyne/v2/theme"
 	"fyne.io/fyne/v2/widget"
+	"github.com/cahlchang/voice-pitch-monitor/reftone"
+	"github.com/cahlchang/voice-pitch-monitor/wavsource"
 	"github.com/gen2brain/malgo"
 )
 
@@ -31,6 +36,11 @@ const (
 	silenceHold       = 3 * time.Second
 	barFullScaleCents = 200.0 // ~B3 vs A3 difference fills the bar
 	freqSmoothTau     = 350 * time.Millisecond
+	echoGuardCents    = 5.0
+	echoGuardRMS      = 0.02
+	echoGuardHold     = 500 * time.Millisecond
+	yinThreshold      = 0.15 // cumulative-mean-normalized difference cutoff for an "absolute" match
+	yinConfidenceMax  = 0.20 // above this, even the best candidate is treated as no pitch
 )
 
 type deviceOption struct {
@@ -57,6 +67,46 @@ func (r *referenceState) get() (string, float64) {
 	return r.note, r.freq
 }
 
+// refTonePlaying reports whether the reference-tone player is currently
+// audible, so the capture analysis loop knows when the echo guard
+// (mic picking up the ref tone from speakers) applies.
+type refTonePlaying struct {
+	mu     sync.RWMutex
+	active bool
+}
+
+// scrubDragState reports whether the user is actively dragging the scrub
+// slider, so the playback goroutine pushing its own progress doesn't
+// fight a live drag by yanking the handle back under the user's finger.
+type scrubDragState struct {
+	mu     sync.RWMutex
+	active bool
+}
+
+func (s *scrubDragState) set(v bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.active = v
+}
+
+func (s *scrubDragState) get() bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.active
+}
+
+func (r *refTonePlaying) set(v bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.active = v
+}
+
+func (r *refTonePlaying) get() bool {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.active
+}
+
 type audioRunner struct {
 	stop func()
 	mu   sync.Mutex
@@ -88,7 +138,7 @@ func main() {
 
 	a := app.NewWithID("voice-pitch-monitor")
 	w := a.NewWindow("Pitch Monitor")
-	w.Resize(fyne.NewSize(260, 140))
+	w.Resize(fyne.NewSize(260, 380))
 	w.SetFixedSize(true)
 	prefs := a.Preferences()
 
@@ -158,7 +208,107 @@ func main() {
 		refSelect.SetSelected(refNotes[idx])
 	}
 
+	midiRun := &audioRunner{}
+	midiDevices, err := midiInputDevices()
+	if err != nil {
+		log.Printf("midi: %v", err)
+	}
+	midiNames := make([]string, len(midiDevices))
+	for i, d := range midiDevices {
+		midiNames[i] = d.Name
+	}
+	lastMIDIPort := prefs.String("last_midi_port")
+	midiSelect := widget.NewSelect(midiNames, func(name string) {
+		selected := findMIDIDeviceByName(midiDevices, name)
+		if selected == nil {
+			return
+		}
+		prefs.SetString("last_midi_port", name)
+		stop, err := startMIDIStream(selected.Port, ref, func(note string) {
+			refLabel.SetText(fmt.Sprintf("Ref: %s", note))
+			if idx := indexOf(refNotes, note); idx >= 0 {
+				refSelect.SetSelected(refNotes[idx])
+			}
+			prefs.SetString("last_ref_note", note)
+		})
+		if err != nil {
+			_ = statusText.Set(fmt.Sprintf("MIDI error: %v", err))
+			return
+		}
+		midiRun.replace(stop)
+	})
+	midiSelect.PlaceHolder = "MIDI keyboard"
+	if idx := indexOf(midiNames, lastMIDIPort); idx >= 0 {
+		midiSelect.SetSelected(lastMIDIPort)
+	}
+
 	pitchBar := NewPitchBar()
+	historySeconds := prefs.FloatWithFallback("history_window_seconds", 10)
+	pitchHistory := NewPitchHistory(ref, time.Duration(historySeconds*float64(time.Second)))
+
+	tonePlaying := &refTonePlaying{}
+	toneRunner := &audioRunner{}
+	tonePlayer := reftone.NewPlayer(reftone.Sine)
+	var toneGen int64 // bumped on every playRefCheck toggle, so a delayed mute-shutdown doesn't tear down a device a fast re-check already started
+
+	waveformNames := []string{"Sine", "Triangle", "Soft Square"}
+	waveformByName := map[string]reftone.Waveform{
+		"Sine":        reftone.Sine,
+		"Triangle":    reftone.Triangle,
+		"Soft Square": reftone.SoftSquare,
+	}
+	waveformSelect := widget.NewSelect(waveformNames, func(name string) {
+		if wf, ok := waveformByName[name]; ok {
+			tonePlayer.SetWaveform(wf)
+			prefs.SetString("ref_tone_waveform", name)
+		}
+	})
+	waveformSelect.PlaceHolder = "Waveform"
+	if idx := indexOf(waveformNames, prefs.StringWithFallback("ref_tone_waveform", "Sine")); idx >= 0 {
+		waveformSelect.SetSelected(waveformNames[idx])
+	}
+
+	toneVolume := prefs.FloatWithFallback("ref_tone_volume", 0.5)
+	tonePlayer.SetVolume(toneVolume)
+	volumeSlider := widget.NewSlider(0, 1)
+	volumeSlider.Step = 0.01
+	volumeSlider.Value = toneVolume
+	volumeSlider.OnChanged = func(v float64) {
+		tonePlayer.SetVolume(v)
+		prefs.SetFloat("ref_tone_volume", v)
+	}
+
+	playRefCheck := widget.NewCheck("Play ref", func(checked bool) {
+		gen := atomic.AddInt64(&toneGen, 1)
+		if !checked {
+			tonePlayer.SetMuted(true)
+			tonePlaying.set(false)
+			// Tearing down the playback device immediately would cut the
+			// tone off mid-ramp and produce the exact click muting is
+			// supposed to avoid; give the envelope time to reach silence
+			// first. Guard against a quick re-check starting a new device
+			// before this fires, which would otherwise tear that one down
+			// instead.
+			go func() {
+				time.Sleep(reftone.EnvelopeDuration)
+				if atomic.LoadInt64(&toneGen) == gen {
+					toneRunner.shutdown()
+				}
+			}()
+			return
+		}
+		stop, err := tonePlayer.Start(ctx, func() float64 {
+			_, freq := ref.get()
+			return freq
+		})
+		if err != nil {
+			_ = statusText.Set(fmt.Sprintf("Ref tone error: %v", err))
+			return
+		}
+		toneRunner.replace(stop)
+		tonePlayer.SetMuted(false)
+		tonePlaying.set(true)
+	})
 
 	lastDevice := prefs.String("last_device")
 	deviceSelect := widget.NewSelect(deviceNames, func(name string) {
@@ -169,7 +319,7 @@ func main() {
 		}
 		prefs.SetString("last_device", name)
 		_ = statusText.Set("Starting mic...")
-		stop, err := startStream(ctx, selected.Info, ref, pitchBar, freqText, noteText, statusText)
+		stop, err := startStream(ctx, selected.Info, ref, tonePlaying, pitchBar, pitchHistory, freqText, noteText, statusText)
 		if err != nil {
 			_ = statusText.Set(fmt.Sprintf("Error: %v", err))
 			return
@@ -186,6 +336,87 @@ func main() {
 		}
 	}
 
+	var activePlayback *filePlayback
+	var playPauseButton *widget.Button
+	var exportButton *widget.Button
+	scrubDrag := &scrubDragState{}
+	scrubSlider := widget.NewSlider(0, 1)
+	scrubSlider.OnChanged = func(v float64) {
+		scrubDrag.set(true)
+		if activePlayback != nil {
+			activePlayback.Seek(time.Duration(v * float64(time.Second)))
+		}
+	}
+	scrubSlider.OnChangeEnded = func(float64) {
+		scrubDrag.set(false)
+	}
+	// setScrubQuiet moves the slider to reflect actual playback progress
+	// without OnChanged treating it as a user seek.
+	setScrubQuiet := func(v float64) {
+		onChanged := scrubSlider.OnChanged
+		scrubSlider.OnChanged = nil
+		scrubSlider.SetValue(v)
+		scrubSlider.OnChanged = onChanged
+	}
+	playbackProgress := binding.NewFloat()
+	playbackProgress.AddListener(binding.NewDataListener(func() {
+		if scrubDrag.get() {
+			return
+		}
+		v, err := playbackProgress.Get()
+		if err != nil {
+			return
+		}
+		setScrubQuiet(v)
+	}))
+	scrubSlider.Disable()
+	playPauseButton = widget.NewButton("Play/Pause", func() {
+		if activePlayback != nil {
+			activePlayback.togglePause()
+		}
+	})
+	exportButton = widget.NewButton("Export CSV...", func() {
+		if activePlayback == nil {
+			return
+		}
+		dialog.ShowFileSave(func(writer fyne.URIWriteCloser, err error) {
+			if err != nil || writer == nil {
+				return
+			}
+			path := writer.URI().Path()
+			_ = writer.Close()
+			if err := activePlayback.exportCSV(path); err != nil {
+				_ = statusText.Set(fmt.Sprintf("Export error: %v", err))
+			}
+		}, w)
+	})
+	playPauseButton.Disable()
+	exportButton.Disable()
+
+	loadFileButton := widget.NewButton("Load file...", func() {
+		dialog.ShowFileOpen(func(reader fyne.URIReadCloser, err error) {
+			if err != nil || reader == nil {
+				return
+			}
+			path := reader.URI().Path()
+			_ = reader.Close()
+			_ = statusText.Set("Loading file...")
+			fp, stop, err := startFilePlayback(path, ref, pitchBar, pitchHistory, freqText, noteText, statusText, playbackProgress)
+			if err != nil {
+				_ = statusText.Set(fmt.Sprintf("Error: %v", err))
+				return
+			}
+			activePlayback = fp
+			scrubSlider.Max = fp.Duration().Seconds()
+			setScrubQuiet(0)
+			scrubSlider.Enable()
+			playPauseButton.Enable()
+			exportButton.Enable()
+			runner.replace(stop)
+			_ = statusText.Set(fmt.Sprintf("Playing %s", filepath.Base(path)))
+		}, w)
+	})
+
 	leftCol := container.NewVBox(
 		container.NewCenter(noteLabel),
 		container.NewCenter(freqLabel),
@@ -194,18 +425,27 @@ func main() {
 	rightCol := container.NewVBox(
 		refLabel,
 		refSelect,
+		midiSelect,
+		playRefCheck,
+		waveformSelect,
+		volumeSlider,
 	)
 
 	content := container.NewVBox(
 		deviceSelect,
 		container.NewGridWithColumns(2, leftCol, rightCol),
+		pitchHistory,
+		scrubSlider,
+		container.NewGridWithColumns(3, loadFileButton, playPauseButton, exportButton),
 		statusLabel,
 	)
 	w.SetContent(content)
 
-	// Stop audio cleanly on window close or Ctrl+C.
+	// Stop audio, MIDI, and the ref tone cleanly on window close or Ctrl+C.
 	w.SetCloseIntercept(func() {
 		runner.shutdown()
+		midiRun.shutdown()
+		toneRunner.shutdown()
 		a.Quit()
 	})
 	quit := make(chan os.Signal, 1)
@@ -213,6 +453,8 @@ func main() {
 	go func() {
 		<-quit
 		runner.shutdown()
+		midiRun.shutdown()
+		toneRunner.shutdown()
 		w.Close()
 	}()
 
@@ -249,7 +491,7 @@ func findDeviceByName(devs []deviceOption, name string) *deviceOption {
 	return nil
 }
 
-func startStream(ctx *malgo.AllocatedContext, info *malgo.DeviceInfo, ref *referenceState, bar *PitchBar, freqText, noteText, statusText binding.String) (func(), error) {
+func startStream(ctx *malgo.AllocatedContext, info *malgo.DeviceInfo, ref *referenceState, tonePlaying *refTonePlaying, bar *PitchBar, hist *PitchHistory, freqText, noteText, statusText binding.String) (func(), error) {
 	config := malgo.DefaultDeviceConfig(malgo.Capture)
 	config.Capture.Format = malgo.FormatF32
 	config.Capture.Channels = 1
@@ -257,7 +499,7 @@ func startStream(ctx *malgo.AllocatedContext, info *malgo.DeviceInfo, ref *refer
 	config.Capture.DeviceID = info.ID.Pointer()
 	config.Alsa.NoMMap = 1
 
-	samplesCh := make(chan []float32, 8)
+	framesCh := make(chan analysisFrame, 8)
 	deviceCallbacks := malgo.DeviceCallbacks{
 		Data: func(output, input []byte, frameCount uint32) {
 			if len(input) == 0 {
@@ -267,7 +509,7 @@ func startStream(ctx *malgo.AllocatedContext, info *malgo.DeviceInfo, ref *refer
 			buf := make([]float32, len(samples))
 			copy(buf, samples)
 			select {
-			case samplesCh <- buf:
+			case framesCh <- analysisFrame{buf: buf, now: time.Now()}:
 			default:
 			}
 		},
@@ -283,55 +525,7 @@ func startStream(ctx *malgo.AllocatedContext, info *malgo.DeviceInfo, ref *refer
 	}
 
 	stop := make(chan struct{})
-	var lastDetected time.Time
-	var lastRMS float64
-	var smoothedFreq float64
-	var lastSmooth time.Time
-	go func() {
-		sampleRate := float64(config.SampleRate)
-		for {
-			select {
-			case <-stop:
-				return
-			case buf := <-samplesCh:
-				freq, rms := detectPitch(buf, sampleRate)
-				if freq <= 0 {
-					if rms < silenceFloor && !lastDetected.IsZero() && time.Since(lastDetected) < silenceHold {
-						_ = statusText.Set(fmt.Sprintf("Holding (Level %.2f)", lastRMS))
-						continue
-					}
-					lastDetected = time.Time{}
-					smoothedFreq = 0
-					lastSmooth = time.Time{}
-					_ = freqText.Set("-- Hz")
-					_ = noteText.Set("--")
-					bar.SetDelta(0)
-					_ = statusText.Set("Listening...")
-					continue
-				}
-				lastDetected = time.Now()
-				lastRMS = rms
-				now := time.Now()
-				if lastSmooth.IsZero() {
-					smoothedFreq = freq
-				} else {
-					smoothedFreq = smoothFreq(smoothedFreq, now.Sub(lastSmooth), freq)
-				}
-				lastSmooth = now
-
-				name, cents := freqToNote(smoothedFreq)
-				_, refFreq := ref.get()
-				if refFreq <= 0 {
-					refFreq = 440.0
-				}
-				deltaCents := 1200 * math.Log2(smoothedFreq/refFreq)
-				bar.SetDelta(deltaCents)
-				_ = freqText.Set(fmt.Sprintf("%.1f Hz", smoothedFreq))
-				_ = noteText.Set(fmt.Sprintf("%s (%+.0f¢)", name, cents))
-				_ = statusText.Set(fmt.Sprintf("Level %.2f", rms))
-			}
-		}
-	}()
+	go runAnalysisLoop(stop, framesCh, float64(config.SampleRate), ref, tonePlaying.get, bar, hist, freqText, noteText, statusText, nil)
 
 	return func() {
 		close(stop)
@@ -340,6 +534,137 @@ func startStream(ctx *malgo.AllocatedContext, info *malgo.DeviceInfo, ref *refer
 	}, nil
 }
 
+// analysisFrame is one chunk of audio ready for analysis, paired with the
+// time it should be treated as captured at (wall-clock for live capture,
+// virtual elapsed time for file playback) — the shape both startStream
+// and startFilePlayback feed into runAnalysisLoop so the two input
+// sources drive the UI and history through identical logic instead of
+// each hand-rolling its own loop.
+type analysisFrame struct {
+	buf []float32
+	now time.Time
+}
+
+// runAnalysisLoop drains framesCh until stop fires or framesCh is closed,
+// running each frame through state.process the same way regardless of
+// which input source produced it. onResult, if non-nil, runs after
+// applyResult/hist.Push with each result and the frame's time, for a
+// source that needs to do more with it, such as file playback recording
+// a CSV row.
+func runAnalysisLoop(stop <-chan struct{}, framesCh <-chan analysisFrame, sampleRate float64, ref *referenceState, tonePlaying func() bool, bar *PitchBar, hist *PitchHistory, freqText, noteText, statusText binding.String, onResult func(analysisResult, time.Time)) {
+	state := &analysisState{}
+	for {
+		select {
+		case <-stop:
+			return
+		case frame, ok := <-framesCh:
+			if !ok {
+				return
+			}
+			res := state.process(frame.buf, sampleRate, ref, tonePlaying(), frame.now)
+			applyResult(res, bar, freqText, noteText, statusText)
+			hist.Push(frame.now, res.freq, res.rms)
+			if onResult != nil {
+				onResult(res, frame.now)
+			}
+		}
+	}
+}
+
+// analysisState holds the smoothing and silence-hold bookkeeping shared by
+// every input source (live capture or a loaded file), so they all drive
+// the UI through the same logic.
+type analysisState struct {
+	lastDetected time.Time
+	lastRMS      float64
+	smoothedFreq float64
+	lastSmooth   time.Time
+	echoSince    time.Time
+}
+
+// analysisResult is what processing a single frame produces for the UI.
+type analysisResult struct {
+	holding    bool
+	freq       float64 // smoothed Hz; 0 means silent
+	note       string
+	cents      float64
+	deltaCents float64
+	rms        float64
+	status     string
+}
+
+// process runs one frame through pitch detection, smoothing, and the
+// silence-hold UX, given the wall-clock (or virtual, for file playback)
+// time the frame was captured at. tonePlaying gates the echo guard, which
+// holds the last display instead of updating it when the mic appears to
+// be picking up the reference tone from speakers rather than a voice.
+func (s *analysisState) process(buf []float32, sampleRate float64, ref *referenceState, tonePlaying bool, now time.Time) analysisResult {
+	freq, rms := detectPitch(buf, sampleRate)
+	if freq <= 0 {
+		s.echoSince = time.Time{}
+		if rms < silenceFloor && !s.lastDetected.IsZero() && now.Sub(s.lastDetected) < silenceHold {
+			return analysisResult{holding: true, status: fmt.Sprintf("Holding (Level %.2f)", s.lastRMS)}
+		}
+		s.lastDetected = time.Time{}
+		s.smoothedFreq = 0
+		s.lastSmooth = time.Time{}
+		return analysisResult{status: "Listening..."}
+	}
+
+	s.lastDetected = now
+	s.lastRMS = rms
+	if s.lastSmooth.IsZero() {
+		s.smoothedFreq = freq
+	} else {
+		s.smoothedFreq = smoothFreq(s.smoothedFreq, now.Sub(s.lastSmooth), freq)
+	}
+	s.lastSmooth = now
+
+	name, cents := freqToNote(s.smoothedFreq)
+	_, refFreq := ref.get()
+	if refFreq <= 0 {
+		refFreq = 440.0
+	}
+	deltaCents := 1200 * math.Log2(s.smoothedFreq/refFreq)
+
+	if tonePlaying && math.Abs(deltaCents) <= echoGuardCents && rms < echoGuardRMS {
+		if s.echoSince.IsZero() {
+			s.echoSince = now
+		} else if now.Sub(s.echoSince) >= echoGuardHold {
+			return analysisResult{holding: true, status: "Muted (echo guard)"}
+		}
+	} else {
+		s.echoSince = time.Time{}
+	}
+
+	return analysisResult{
+		freq:       s.smoothedFreq,
+		note:       name,
+		cents:      cents,
+		deltaCents: deltaCents,
+		rms:        rms,
+		status:     fmt.Sprintf("Level %.2f", rms),
+	}
+}
+
+// applyResult pushes an analysisResult onto the same bindings startStream
+// has always driven, regardless of which input source produced it.
+func applyResult(res analysisResult, bar *PitchBar, freqText, noteText, statusText binding.String) {
+	_ = statusText.Set(res.status)
+	if res.holding {
+		return
+	}
+	if res.freq <= 0 {
+		_ = freqText.Set("-- Hz")
+		_ = noteText.Set("--")
+		bar.SetDelta(0)
+		return
+	}
+	bar.SetDelta(res.deltaCents)
+	_ = freqText.Set(fmt.Sprintf("%.1f Hz", res.freq))
+	_ = noteText.Set(fmt.Sprintf("%s (%+.0f¢)", res.note, res.cents))
+}
+
 func chooseSampleRate(info *malgo.DeviceInfo) uint32 {
 	for _, f := range info.Formats {
 		if f.SampleRate > 0 {
@@ -356,6 +681,13 @@ func bytesToFloat32Slice(b []byte) []float32 {
 	return *(*[]float32)(unsafe.Pointer(&hdr))
 }
 
+// detectPitch estimates the fundamental frequency of samples using YIN
+// (de Cheveigné & Kawahara): a cumulative-mean-normalized difference
+// function is far less prone to octave errors than plain autocorrelation,
+// which is what made voice input unreliable before. Gated on RMS silence
+// as the autocorrelation version was, and also on a confidence cutoff on
+// the normalized difference; the signature is unchanged, so callers don't
+// need to change.
 func detectPitch(samples []float32, sampleRate float64) (float64, float64) {
 	if len(samples) == 0 {
 		return 0, 0
@@ -367,10 +699,10 @@ func detectPitch(samples []float32, sampleRate float64) (float64, float64) {
 	mean := sum / float64(len(samples))
 
 	var energy float64
-	normalized := make([]float64, len(samples))
+	x := make([]float64, len(samples))
 	for i, s := range samples {
 		v := float64(s) - mean
-		normalized[i] = v
+		x[i] = v
 		energy += v * v
 	}
 	rms := math.Sqrt(energy / float64(len(samples)))
@@ -378,34 +710,95 @@ func detectPitch(samples []float32, sampleRate float64) (float64, float64) {
 		return 0, rms
 	}
 
-	minLag := int(sampleRate / maxPitchHz)
-	maxLag := int(sampleRate / minPitchHz)
-	if maxLag >= len(normalized) {
-		maxLag = len(normalized) - 1
+	tauMin := int(sampleRate / maxPitchHz)
+	if tauMin < 1 {
+		tauMin = 1
+	}
+	tauMax := int(sampleRate / minPitchHz)
+	if tauMax >= len(x) {
+		tauMax = len(x) - 1
+	}
+	if tauMax <= tauMin {
+		return 0, rms
+	}
+
+	// Difference function d(tau) over a fixed window of len(x)-tauMax
+	// samples, so x[i+tau] stays in bounds for every tau in range.
+	w := len(x) - tauMax
+	d := make([]float64, tauMax+1)
+	for tau := tauMin; tau <= tauMax; tau++ {
+		var sumSq float64
+		for i := 0; i < w; i++ {
+			diff := x[i] - x[i+tau]
+			sumSq += diff * diff
+		}
+		d[tau] = sumSq
+	}
+
+	// Cumulative-mean normalization flattens the tendency of d(tau) to
+	// grow with tau, so a true period shows up as a clear dip rather than
+	// the smallest-tau-wins bias plain autocorrelation has.
+	cmnd := make([]float64, tauMax+1)
+	cmnd[0] = 1
+	var runningSum float64
+	for tau := 1; tau <= tauMax; tau++ {
+		runningSum += d[tau]
+		if runningSum == 0 {
+			cmnd[tau] = 1
+		} else {
+			cmnd[tau] = d[tau] * float64(tau) / runningSum
+		}
 	}
 
-	var bestLag int
-	var bestCorr float64
-	for lag := minLag; lag <= maxLag; lag++ {
-		var corr float64
-		for i := 0; i < len(normalized)-lag; i++ {
-			corr += normalized[i] * normalized[i+lag]
+	bestTau := -1
+	for tau := tauMin; tau <= tauMax; tau++ {
+		if cmnd[tau] >= yinThreshold {
+			continue
 		}
-		if corr > bestCorr {
-			bestCorr = corr
-			bestLag = lag
+		for tau+1 <= tauMax && cmnd[tau+1] < cmnd[tau] {
+			tau++
+		}
+		bestTau = tau
+		break
+	}
+	if bestTau == -1 {
+		bestTau = tauMin
+		for tau := tauMin + 1; tau <= tauMax; tau++ {
+			if cmnd[tau] < cmnd[bestTau] {
+				bestTau = tau
+			}
 		}
 	}
-	if bestLag == 0 {
+	if cmnd[bestTau] > yinConfidenceMax {
 		return 0, rms
 	}
-	freq := sampleRate / float64(bestLag)
+
+	fracTau := parabolicInterpolateTau(cmnd, bestTau, tauMin, tauMax)
+	if fracTau <= 0 {
+		return 0, rms
+	}
+	freq := sampleRate / fracTau
 	if freq < minPitchHz || freq > maxPitchHz {
 		return 0, rms
 	}
 	return freq, rms
 }
 
+// parabolicInterpolateTau refines the integer tau* to a fractional lag by
+// fitting a parabola through (tau-1, tau, tau+1) on the normalized
+// difference function.
+func parabolicInterpolateTau(cmnd []float64, tau, lo, hi int) float64 {
+	if tau <= lo || tau >= hi {
+		return float64(tau)
+	}
+	s0, s1, s2 := cmnd[tau-1], cmnd[tau], cmnd[tau+1]
+	denom := 2 * (s0 - 2*s1 + s2)
+	if denom == 0 {
+		return float64(tau)
+	}
+	return float64(tau) + (s0-s2)/denom
+}
+
 func freqToNote(freq float64) (string, float64) {
 	if freq <= 0 {
 		return "--", 0
@@ -2,6 +2,7 @@ package main
 
 import (
 	"math"
+	"math/rand"
 	"testing"
 	"time"
 )
@@ -58,6 +59,71 @@ func TestDetectPitchSine(t *testing.T) {
 	}
 }
 
+func TestDetectPitchYIN(t *testing.T) {
+	sampleRate := 48000.0
+	cases := []struct {
+		name      string
+		samples   []float32
+		wantFreq  float64
+		tolerance float64
+	}{
+		{
+			name: "pure sine",
+			samples: func() []float32 {
+				samples := make([]float32, 2048)
+				for i := range samples {
+					samples[i] = float32(0.6 * math.Sin(2*math.Pi*196.0*float64(i)/sampleRate))
+				}
+				return samples
+			}(),
+			wantFreq:  196.0,
+			tolerance: 2.0,
+		},
+		{
+			// A strong octave-above harmonic is exactly what used to
+			// make the old autocorrelation loop octave-halve.
+			name: "fundamental plus octave harmonic",
+			samples: func() []float32 {
+				samples := make([]float32, 2048)
+				for i := range samples {
+					t := float64(i) / sampleRate
+					samples[i] = float32(0.5*math.Sin(2*math.Pi*150*t) + 0.3*math.Sin(2*math.Pi*300*t))
+				}
+				return samples
+			}(),
+			wantFreq:  150.0,
+			tolerance: 3.0,
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			freq, rms := detectPitch(tc.samples, sampleRate)
+			if rms <= silenceFloor {
+				t.Fatalf("expected audible rms, got %.4f", rms)
+			}
+			if math.Abs(freq-tc.wantFreq) > tc.tolerance {
+				t.Fatalf("expected freq near %.1f, got %.2f", tc.wantFreq, freq)
+			}
+		})
+	}
+}
+
+func TestDetectPitchNoiseReportsZero(t *testing.T) {
+	sampleRate := 48000.0
+	rng := rand.New(rand.NewSource(1))
+	samples := make([]float32, 2048)
+	for i := range samples {
+		samples[i] = float32(rng.Float64()*2 - 1)
+	}
+	freq, rms := detectPitch(samples, sampleRate)
+	if rms <= silenceFloor {
+		t.Fatalf("expected audible rms for this test to be meaningful, got %.4f", rms)
+	}
+	if freq != 0 {
+		t.Fatalf("expected 0 freq for noise, got %.2f", freq)
+	}
+}
+
 func TestDetectPitchSilence(t *testing.T) {
 	samples := make([]float32, 2048)
 	freq, rms := detectPitch(samples, 48000)
@@ -83,3 +149,44 @@ func TestSmoothFreq(t *testing.T) {
 		t.Fatalf("expected near %.2f, got %.2f", expected, s)
 	}
 }
+
+func echoLikeFrame(sampleRate, freq, amp float64) []float32 {
+	samples := make([]float32, 2048)
+	for i := range samples {
+		samples[i] = float32(amp * math.Sin(2*math.Pi*freq*float64(i)/sampleRate))
+	}
+	return samples
+}
+
+func TestAnalysisStateEchoGuardEngagesWhenToneIsPlaying(t *testing.T) {
+	sampleRate := 48000.0
+	ref := &referenceState{}
+	ref.set("A3", 220.0)
+	samples := echoLikeFrame(sampleRate, 220.0, 0.014) // rms ~0.0099, below echoGuardRMS
+
+	state := &analysisState{}
+	start := time.Now()
+	if res := state.process(samples, sampleRate, ref, true, start); res.holding {
+		t.Fatalf("expected no hold on the first near-ref low-level frame")
+	}
+
+	res := state.process(samples, sampleRate, ref, true, start.Add(600*time.Millisecond))
+	if !res.holding || res.status != "Muted (echo guard)" {
+		t.Fatalf("expected echo guard to engage after sustained near-ref low-level signal, got %+v", res)
+	}
+}
+
+func TestAnalysisStateEchoGuardOnlyAppliesWhenTonePlaying(t *testing.T) {
+	sampleRate := 48000.0
+	ref := &referenceState{}
+	ref.set("A3", 220.0)
+	samples := echoLikeFrame(sampleRate, 220.0, 0.014)
+
+	state := &analysisState{}
+	start := time.Now()
+	state.process(samples, sampleRate, ref, false, start)
+	res := state.process(samples, sampleRate, ref, false, start.Add(600*time.Millisecond))
+	if res.holding {
+		t.Fatalf("expected no echo guard hold when the ref tone isn't playing")
+	}
+}
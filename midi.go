@@ -0,0 +1,144 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+
+	"gitlab.com/gomidi/midi/v2"
+	"gitlab.com/gomidi/midi/v2/drivers"
+	"gitlab.com/gomidi/midi/v2/drivers/rtmididrv"
+)
+
+type midiDeviceOption struct {
+	Name string
+	Port drivers.In
+}
+
+// midiInputDevices lists the MIDI input ports visible to rtmidi, the same
+// way inputDevices lists malgo capture devices.
+func midiInputDevices() ([]midiDeviceOption, error) {
+	drv, err := rtmididrv.New()
+	if err != nil {
+		return nil, fmt.Errorf("rtmidi driver: %w", err)
+	}
+	ins, err := drv.Ins()
+	if err != nil {
+		return nil, fmt.Errorf("list midi inputs: %w", err)
+	}
+	devs := make([]midiDeviceOption, 0, len(ins))
+	for _, in := range ins {
+		devs = append(devs, midiDeviceOption{Name: in.String(), Port: in})
+	}
+	return devs, nil
+}
+
+func findMIDIDeviceByName(devs []midiDeviceOption, name string) *midiDeviceOption {
+	for i := range devs {
+		if devs[i].Name == name {
+			return &devs[i]
+		}
+	}
+	return nil
+}
+
+// heldNotes tracks currently-held MIDI notes so the lowest one can become
+// the reference pitch, the way a singer reads the bottom note of a
+// keyboard chord as the target.
+type heldNotes struct {
+	mu    sync.Mutex
+	midis map[uint8]struct{}
+}
+
+func newHeldNotes() *heldNotes {
+	return &heldNotes{midis: make(map[uint8]struct{})}
+}
+
+func (h *heldNotes) add(note uint8) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.midis[note] = struct{}{}
+}
+
+func (h *heldNotes) remove(note uint8) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	delete(h.midis, note)
+}
+
+// lowest returns the lowest currently-held note, or ok=false if nothing is
+// held.
+func (h *heldNotes) lowest() (note uint8, ok bool) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if len(h.midis) == 0 {
+		return 0, false
+	}
+	notes := make([]uint8, 0, len(h.midis))
+	for n := range h.midis {
+		notes = append(notes, n)
+	}
+	sort.Slice(notes, func(i, j int) bool { return notes[i] < notes[j] })
+	return notes[0], true
+}
+
+// processNoteEvent folds a single NoteOn/NoteOff event into held, and if
+// the lowest held note's frequency differs from ref's current one, sets
+// ref to it. It returns the lowest held note's name ("" if none is held)
+// and whether ref was changed.
+func processNoteEvent(held *heldNotes, ref *referenceState, noteOn bool, key, vel uint8) (note string, changed bool) {
+	if noteOn && vel > 0 {
+		held.add(key)
+	} else {
+		held.remove(key)
+	}
+
+	lowest, ok := held.lowest()
+	if !ok {
+		return "", false
+	}
+	name := midiToName(int(lowest))
+	freq := midiToFreq(int(lowest))
+	_, curFreq := ref.get()
+	if curFreq == freq {
+		return name, false
+	}
+	ref.set(name, freq)
+	return name, true
+}
+
+// startMIDIStream opens in and calls onChange with the new reference note
+// name each time processNoteEvent changes ref. Swapping MIDI ports cleanly
+// tears down the previous listener via its own audioRunner, the same
+// mechanism the capture device and ref tone use.
+func startMIDIStream(in drivers.In, ref *referenceState, onChange func(note string)) (func(), error) {
+	if err := in.Open(); err != nil {
+		return nil, fmt.Errorf("open midi port: %w", err)
+	}
+
+	held := newHeldNotes()
+	stopListening, err := midi.ListenTo(in, func(msg midi.Message, timestampms int32) {
+		var ch, key, vel uint8
+		var noteOn bool
+		switch {
+		case msg.GetNoteOn(&ch, &key, &vel):
+			noteOn = true
+		case msg.GetNoteOff(&ch, &key, &vel):
+			noteOn = false
+		default:
+			return
+		}
+		if note, changed := processNoteEvent(held, ref, noteOn, key, vel); changed && onChange != nil {
+			onChange(note)
+		}
+	})
+	if err != nil {
+		_ = in.Close()
+		return nil, fmt.Errorf("listen to midi port: %w", err)
+	}
+
+	return func() {
+		stopListening()
+		_ = in.Close()
+	}, nil
+}
@@ -0,0 +1,121 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+
+	"gitlab.com/gomidi/midi/v2/drivers"
+)
+
+func TestProcessNoteEventLowestWins(t *testing.T) {
+	held := newHeldNotes()
+	ref := &referenceState{}
+
+	note, changed := processNoteEvent(held, ref, true, 69, 100) // A4
+	if !changed || note != "A4" {
+		t.Fatalf("expected A4 on first note-on, got %q changed=%v", note, changed)
+	}
+
+	note, changed = processNoteEvent(held, ref, true, 60, 100) // C4, lower
+	if !changed || note != "C4" {
+		t.Fatalf("expected C4 once a lower note is held, got %q changed=%v", note, changed)
+	}
+
+	// A higher note joining the chord shouldn't move the reference.
+	note, changed = processNoteEvent(held, ref, true, 72, 100) // C5
+	if changed {
+		t.Fatalf("expected no change when a higher note joins, got %q", note)
+	}
+
+	// Releasing the lowest note should fall back to the next-lowest held note.
+	note, changed = processNoteEvent(held, ref, false, 60, 0)
+	if !changed || note != "A4" {
+		t.Fatalf("expected fallback to A4 after releasing C4, got %q changed=%v", note, changed)
+	}
+}
+
+func TestProcessNoteEventNoteOnVelocityZeroIsNoteOff(t *testing.T) {
+	held := newHeldNotes()
+	ref := &referenceState{}
+
+	if _, changed := processNoteEvent(held, ref, true, 64, 100); !changed {
+		t.Fatalf("expected change on initial note-on")
+	}
+	if _, ok := held.lowest(); !ok {
+		t.Fatalf("expected note to be held")
+	}
+
+	// A NoteOn with velocity 0 is conventionally a NoteOff.
+	processNoteEvent(held, ref, true, 64, 0)
+	if _, ok := held.lowest(); ok {
+		t.Fatalf("expected no notes held after velocity-0 note-on")
+	}
+}
+
+func TestProcessNoteEventAllNotesReleased(t *testing.T) {
+	held := newHeldNotes()
+	ref := &referenceState{}
+
+	processNoteEvent(held, ref, true, 69, 100)
+	note, changed := processNoteEvent(held, ref, false, 69, 0)
+	if changed {
+		t.Fatalf("expected no reference change once all notes are released, got %q", note)
+	}
+	if _, ok := held.lowest(); ok {
+		t.Fatalf("expected no notes held")
+	}
+}
+
+// fakeMIDIIn is a drivers.In that feeds a fixed sequence of raw messages to
+// whoever calls Listen, synchronously and in order, so startMIDIStream can
+// be exercised without a real MIDI port.
+type fakeMIDIIn struct {
+	msgs   [][]byte
+	opened bool
+	closed bool
+}
+
+func (f *fakeMIDIIn) Open() error             { f.opened = true; return nil }
+func (f *fakeMIDIIn) Close() error            { f.closed = true; return nil }
+func (f *fakeMIDIIn) IsOpen() bool            { return f.opened && !f.closed }
+func (f *fakeMIDIIn) Number() int             { return 0 }
+func (f *fakeMIDIIn) String() string          { return "fake midi in" }
+func (f *fakeMIDIIn) Underlying() interface{} { return nil }
+
+func (f *fakeMIDIIn) Listen(onMsg func(msg []byte, milliseconds int32), config drivers.ListenConfig) (func(), error) {
+	for _, m := range f.msgs {
+		onMsg(m, 0)
+	}
+	return func() {}, nil
+}
+
+func TestStartMIDIStreamUpdatesReference(t *testing.T) {
+	in := &fakeMIDIIn{msgs: [][]byte{
+		{0x90, 69, 100}, // NoteOn A4
+		{0x90, 60, 100}, // NoteOn C4, lower than A4
+	}}
+	ref := &referenceState{}
+	var got []string
+
+	stop, err := startMIDIStream(in, ref, func(note string) {
+		got = append(got, note)
+	})
+	if err != nil {
+		t.Fatalf("startMIDIStream: %v", err)
+	}
+
+	if !in.opened {
+		t.Fatalf("expected port to be opened")
+	}
+	if note, _ := ref.get(); note != "C4" {
+		t.Fatalf("expected reference to settle on the lowest held note C4, got %q", note)
+	}
+	if want := []string{"A4", "C4"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("expected onChange calls %v, got %v", want, got)
+	}
+
+	stop()
+	if !in.closed {
+		t.Fatalf("expected port to be closed after stop")
+	}
+}
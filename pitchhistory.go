@@ -0,0 +1,261 @@
+package main
+
+import (
+	"image/color"
+	"image/png"
+	"math"
+	"sync"
+	"time"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/canvas"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/widget"
+)
+
+const (
+	defaultHistoryWindow  = 10 * time.Second
+	historyFullScaleCents = 400.0
+	historyGuideCents     = 100.0
+)
+
+// pitchHistoryPoint is one sample pushed into a PitchHistory's ring
+// buffer: the same (time, smoothedFreq, rms) shape the analysis loop
+// already produces each tick. freq <= 0 marks a silent frame.
+type pitchHistoryPoint struct {
+	t    time.Time
+	freq float64
+	rms  float64
+}
+
+// PitchHistory is a scrolling piano-roll-style strip of the last window
+// of smoothed pitch relative to a reference note, a sibling to PitchBar
+// for reading a whole phrase instead of a single instant.
+type PitchHistory struct {
+	widget.BaseWidget
+	mu     sync.Mutex
+	ref    *referenceState
+	points []pitchHistoryPoint
+	window time.Duration
+	frozen bool
+}
+
+// NewPitchHistory returns a PitchHistory over ref showing the last
+// window of pitch (defaultHistoryWindow if window <= 0).
+func NewPitchHistory(ref *referenceState, window time.Duration) *PitchHistory {
+	if window <= 0 {
+		window = defaultHistoryWindow
+	}
+	p := &PitchHistory{ref: ref, window: window}
+	p.ExtendBaseWidget(p)
+	return p
+}
+
+// Push records one analyzed frame. It's a no-op while frozen.
+func (p *PitchHistory) Push(t time.Time, freq, rms float64) {
+	p.mu.Lock()
+	if p.frozen {
+		p.mu.Unlock()
+		return
+	}
+	p.points = append(p.points, pitchHistoryPoint{t: t, freq: freq, rms: rms})
+	cutoff := t.Add(-p.window)
+	trim := 0
+	for trim < len(p.points) && p.points[trim].t.Before(cutoff) {
+		trim++
+	}
+	if trim > 0 {
+		p.points = p.points[trim:]
+	}
+	p.mu.Unlock()
+	p.Refresh()
+}
+
+func (p *PitchHistory) snapshot() (pts []pitchHistoryPoint, window time.Duration) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	pts = make([]pitchHistoryPoint, len(p.points))
+	copy(pts, p.points)
+	return pts, p.window
+}
+
+// SetFrozen pauses or resumes recording new points without clearing the
+// strip, for the right-click "Freeze" action.
+func (p *PitchHistory) SetFrozen(frozen bool) {
+	p.mu.Lock()
+	p.frozen = frozen
+	p.mu.Unlock()
+}
+
+// Frozen reports whether the strip is currently frozen.
+func (p *PitchHistory) Frozen() bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.frozen
+}
+
+// Clear empties the strip.
+func (p *PitchHistory) Clear() {
+	p.mu.Lock()
+	p.points = nil
+	p.mu.Unlock()
+	p.Refresh()
+}
+
+// TruncateAt drops any recorded points at or after t. It's for a
+// backward seek during file playback: without it, a point pushed at an
+// earlier virtual time than ones already recorded would make the strip's
+// timeline non-monotonic, breaking both the renderer's "now" reference
+// and where it draws already-recorded points.
+func (p *PitchHistory) TruncateAt(t time.Time) {
+	p.mu.Lock()
+	i := 0
+	for i < len(p.points) && p.points[i].t.Before(t) {
+		i++
+	}
+	p.points = p.points[:i]
+	p.mu.Unlock()
+	p.Refresh()
+}
+
+// TappedSecondary shows the freeze/clear/save-PNG context menu.
+func (p *PitchHistory) TappedSecondary(e *fyne.PointEvent) {
+	freezeLabel := "Freeze"
+	if p.Frozen() {
+		freezeLabel = "Unfreeze"
+	}
+	menu := fyne.NewMenu("",
+		fyne.NewMenuItem(freezeLabel, func() { p.SetFrozen(!p.Frozen()) }),
+		fyne.NewMenuItem("Clear", p.Clear),
+		fyne.NewMenuItem("Save PNG...", p.savePNG),
+	)
+	c := fyne.CurrentApp().Driver().CanvasForObject(p)
+	if c == nil {
+		return
+	}
+	widget.NewPopUpMenu(menu, c).ShowAtPosition(e.AbsolutePosition)
+}
+
+// savePNG rasterizes the widget's current canvas view to a file the user
+// picks.
+func (p *PitchHistory) savePNG() {
+	c := fyne.CurrentApp().Driver().CanvasForObject(p)
+	if c == nil {
+		return
+	}
+	img := c.Capture()
+
+	var parent fyne.Window
+	if windows := fyne.CurrentApp().Driver().AllWindows(); len(windows) > 0 {
+		parent = windows[0]
+	}
+	dialog.ShowFileSave(func(writer fyne.URIWriteCloser, err error) {
+		if err != nil || writer == nil {
+			return
+		}
+		defer writer.Close()
+		_ = png.Encode(writer, img)
+	}, parent)
+}
+
+func (p *PitchHistory) CreateRenderer() fyne.WidgetRenderer {
+	r := &pitchHistoryRenderer{hist: p}
+	r.zeroLine = canvas.NewLine(color.NRGBA{R: 200, G: 200, B: 200, A: 220})
+	guideCount := 4 // +/-100c and +/-200c
+	for i := 0; i < guideCount; i++ {
+		r.guides = append(r.guides, canvas.NewLine(color.NRGBA{R: 150, G: 150, B: 150, A: 80}))
+	}
+	return r
+}
+
+type pitchHistoryRenderer struct {
+	hist     *PitchHistory
+	zeroLine *canvas.Line
+	guides   []*canvas.Line
+	trace    []*canvas.Line
+}
+
+func (r *pitchHistoryRenderer) Layout(size fyne.Size) {
+	toY := func(cents float64) float32 {
+		ratio := float32(cents / historyFullScaleCents)
+		return size.Height/2 - ratio*(size.Height/2)
+	}
+
+	r.zeroLine.Position1 = fyne.NewPos(0, toY(0))
+	r.zeroLine.Position2 = fyne.NewPos(size.Width, toY(0))
+
+	guideCents := []float64{-2 * historyGuideCents, -historyGuideCents, historyGuideCents, 2 * historyGuideCents}
+	for i, g := range r.guides {
+		y := toY(guideCents[i])
+		g.Position1 = fyne.NewPos(0, y)
+		g.Position2 = fyne.NewPos(size.Width, y)
+	}
+
+	pts, window := r.hist.snapshot()
+	_, refFreq := r.hist.ref.get()
+	if refFreq <= 0 {
+		refFreq = 440.0
+	}
+
+	now := time.Now()
+	if len(pts) > 0 {
+		now = pts[len(pts)-1].t
+	}
+	toX := func(t time.Time) float32 {
+		ratio := float32(1 - now.Sub(t).Seconds()/window.Seconds())
+		return ratio * size.Width
+	}
+
+	segments := 0
+	if len(pts) > 1 {
+		segments = len(pts) - 1
+	}
+	for len(r.trace) < segments {
+		r.trace = append(r.trace, canvas.NewLine(color.NRGBA{R: 90, G: 200, B: 120, A: 255}))
+	}
+	r.trace = r.trace[:segments]
+
+	for i := 0; i < segments; i++ {
+		a, b := pts[i], pts[i+1]
+		line := r.trace[i]
+		if a.freq <= 0 || b.freq <= 0 {
+			// Collapse silent gaps to a zero-length line rather than
+			// skipping the index, so r.trace stays aligned with pts.
+			line.Position1 = fyne.NewPos(0, 0)
+			line.Position2 = fyne.NewPos(0, 0)
+			line.Refresh()
+			continue
+		}
+		centsA := 1200 * math.Log2(a.freq/refFreq)
+		centsB := 1200 * math.Log2(b.freq/refFreq)
+		line.Position1 = fyne.NewPos(toX(a.t), toY(centsA))
+		line.Position2 = fyne.NewPos(toX(b.t), toY(centsB))
+		intensity := uint8(60 + 180*math.Min(1, a.rms*20))
+		line.StrokeColor = color.NRGBA{R: 90, G: 200, B: 120, A: intensity}
+		line.StrokeWidth = 2
+		line.Refresh()
+	}
+}
+
+func (r *pitchHistoryRenderer) MinSize() fyne.Size {
+	return fyne.NewSize(160, 70)
+}
+
+func (r *pitchHistoryRenderer) Refresh() {
+	r.Layout(r.hist.Size())
+	canvas.Refresh(r.hist)
+}
+
+func (r *pitchHistoryRenderer) Destroy() {}
+
+func (r *pitchHistoryRenderer) Objects() []fyne.CanvasObject {
+	objs := make([]fyne.CanvasObject, 0, len(r.guides)+len(r.trace)+1)
+	for _, g := range r.guides {
+		objs = append(objs, g)
+	}
+	objs = append(objs, r.zeroLine)
+	for _, t := range r.trace {
+		objs = append(objs, t)
+	}
+	return objs
+}
@@ -0,0 +1,68 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestPitchHistoryTrimsToWindow(t *testing.T) {
+	ref := &referenceState{}
+	h := NewPitchHistory(ref, 2*time.Second)
+
+	base := time.Now()
+	h.Push(base, 220, 0.1)
+	h.Push(base.Add(1*time.Second), 221, 0.1)
+	h.Push(base.Add(3*time.Second), 222, 0.1) // drops the first point
+
+	pts, window := h.snapshot()
+	if window != 2*time.Second {
+		t.Fatalf("expected window to stay 2s, got %v", window)
+	}
+	if len(pts) != 2 {
+		t.Fatalf("expected 2 points after trimming, got %d", len(pts))
+	}
+	if pts[0].freq != 221 || pts[1].freq != 222 {
+		t.Fatalf("expected oldest point dropped, got %+v", pts)
+	}
+}
+
+func TestPitchHistoryFreezeStopsRecording(t *testing.T) {
+	ref := &referenceState{}
+	h := NewPitchHistory(ref, 10*time.Second)
+
+	h.Push(time.Now(), 220, 0.1)
+	h.SetFrozen(true)
+	h.Push(time.Now(), 440, 0.1)
+
+	pts, _ := h.snapshot()
+	if len(pts) != 1 || pts[0].freq != 220 {
+		t.Fatalf("expected frozen history to ignore new pushes, got %+v", pts)
+	}
+
+	h.SetFrozen(false)
+	h.Push(time.Now(), 330, 0.1)
+	pts, _ = h.snapshot()
+	if len(pts) != 2 {
+		t.Fatalf("expected recording to resume after unfreezing, got %d points", len(pts))
+	}
+}
+
+func TestPitchHistoryClear(t *testing.T) {
+	ref := &referenceState{}
+	h := NewPitchHistory(ref, 10*time.Second)
+	h.Push(time.Now(), 220, 0.1)
+	h.Clear()
+
+	pts, _ := h.snapshot()
+	if len(pts) != 0 {
+		t.Fatalf("expected empty history after Clear, got %d points", len(pts))
+	}
+}
+
+func TestNewPitchHistoryDefaultsWindow(t *testing.T) {
+	ref := &referenceState{}
+	h := NewPitchHistory(ref, 0)
+	if _, window := h.snapshot(); window != defaultHistoryWindow {
+		t.Fatalf("expected default window %v, got %v", defaultHistoryWindow, window)
+	}
+}
@@ -0,0 +1,187 @@
+// Package reftone generates a continuous reference tone and streams it
+// through a malgo playback device, so a singer can practice against a
+// held note the way they would against a piano drone. The oscillator's
+// phase advances continuously across frequency changes and its envelope
+// ramps with a raised-cosine curve, so switching notes or muting never
+// pops.
+package reftone
+
+import (
+	"fmt"
+	"math"
+	"reflect"
+	"sync"
+	"time"
+	"unsafe"
+
+	"github.com/gen2brain/malgo"
+)
+
+// Waveform selects the oscillator's shape.
+type Waveform int
+
+const (
+	Sine Waveform = iota
+	Triangle
+	SoftSquare
+)
+
+// EnvelopeDuration is how long a mute/unmute transition takes to ramp,
+// short enough to feel immediate but long enough to avoid a click. A
+// caller tearing down the playback device after muting should wait at
+// least this long first, or the device disappears mid-ramp and clicks
+// anyway.
+const EnvelopeDuration = 15 * time.Millisecond
+
+// Oscillator renders a phase-continuous tone at a settable frequency,
+// waveform, and volume, with a raised-cosine envelope gating on/off.
+type Oscillator struct {
+	mu        sync.Mutex
+	phase     float64
+	freq      float64
+	waveform  Waveform
+	volume    float64
+	envelope  float64
+	envTarget float64
+}
+
+// NewOscillator returns an oscillator at freq, initially muted so the
+// caller can fade it in once a playback device is ready.
+func NewOscillator(waveform Waveform, freq float64) *Oscillator {
+	return &Oscillator{waveform: waveform, freq: freq, volume: 1}
+}
+
+// SetFreq updates the frequency. Render switches to it from the next
+// sample on while keeping the current phase, so calling this while
+// rendering does not restart the waveform.
+func (o *Oscillator) SetFreq(freq float64) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.freq = freq
+}
+
+// SetVolume sets linear output volume in [0,1].
+func (o *Oscillator) SetVolume(v float64) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.volume = v
+}
+
+// SetWaveform changes the oscillator's shape.
+func (o *Oscillator) SetWaveform(w Waveform) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.waveform = w
+}
+
+// SetMuted raises or lowers the envelope target; Render ramps toward it.
+func (o *Oscillator) SetMuted(muted bool) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	if muted {
+		o.envTarget = 0
+	} else {
+		o.envTarget = 1
+	}
+}
+
+// Render fills out with the next len(out) samples at sampleRate.
+func (o *Oscillator) Render(out []float32, sampleRate float64) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	envStep := 1.0 / (EnvelopeDuration.Seconds() * sampleRate)
+	for i := range out {
+		switch {
+		case o.envelope < o.envTarget:
+			o.envelope = math.Min(o.envTarget, o.envelope+envStep)
+		case o.envelope > o.envTarget:
+			o.envelope = math.Max(o.envTarget, o.envelope-envStep)
+		}
+		// Raised-cosine shaping turns the linear ramp into one with no
+		// slope discontinuity at its endpoints, which is what actually
+		// avoids the click (a linear fade alone still pops faintly).
+		shaped := 0.5 - 0.5*math.Cos(math.Pi*o.envelope)
+
+		out[i] = float32(waveformSample(o.waveform, o.phase) * o.volume * shaped)
+
+		o.phase += o.freq / sampleRate
+		if o.phase >= 1 {
+			o.phase -= math.Floor(o.phase)
+		}
+	}
+}
+
+func waveformSample(w Waveform, phase float64) float64 {
+	switch w {
+	case Triangle:
+		return 4*math.Abs(phase-math.Floor(phase+0.5)) - 1
+	case SoftSquare:
+		// A tanh-shaped sine approximates a square wave without the
+		// ideal square's hard edges, which matter less for clicks (the
+		// envelope handles those) than for how harsh a held drone sounds.
+		const softness = 4.0
+		return math.Tanh(softness*math.Sin(2*math.Pi*phase)) / math.Tanh(softness)
+	default:
+		return math.Sin(2 * math.Pi * phase)
+	}
+}
+
+// Player streams an Oscillator through a malgo playback device, polling a
+// caller-supplied frequency function once per callback so the tone tracks
+// an external reference (e.g. referenceState.get) without restarting.
+type Player struct {
+	osc *Oscillator
+}
+
+// NewPlayer returns a Player with its own oscillator, muted until Start
+// and SetMuted(false) are both called.
+func NewPlayer(waveform Waveform) *Player {
+	p := &Player{osc: NewOscillator(waveform, 0)}
+	p.osc.SetMuted(true)
+	return p
+}
+
+func (p *Player) SetVolume(v float64)    { p.osc.SetVolume(v) }
+func (p *Player) SetWaveform(w Waveform) { p.osc.SetWaveform(w) }
+func (p *Player) SetMuted(muted bool)    { p.osc.SetMuted(muted) }
+
+// Start opens a playback device and begins streaming. freqFunc is polled
+// once per callback, typically wired to a referenceState.get, so changing
+// the reference note retunes the tone in place.
+func (p *Player) Start(ctx *malgo.AllocatedContext, freqFunc func() float64) (func(), error) {
+	config := malgo.DefaultDeviceConfig(malgo.Playback)
+	config.Playback.Format = malgo.FormatF32
+	config.Playback.Channels = 1
+	config.SampleRate = 48000
+
+	callbacks := malgo.DeviceCallbacks{
+		Data: func(output, input []byte, frameCount uint32) {
+			p.osc.SetFreq(freqFunc())
+			samples := make([]float32, frameCount)
+			p.osc.Render(samples, float64(config.SampleRate))
+			copy(output, float32SliceToBytes(samples))
+		},
+	}
+
+	device, err := malgo.InitDevice(ctx.Context, config, callbacks)
+	if err != nil {
+		return nil, fmt.Errorf("init playback device: %w", err)
+	}
+	if err := device.Start(); err != nil {
+		device.Uninit()
+		return nil, fmt.Errorf("start playback device: %w", err)
+	}
+
+	return func() {
+		_ = device.Stop()
+		device.Uninit()
+	}, nil
+}
+
+func float32SliceToBytes(s []float32) []byte {
+	hdr := *(*reflect.SliceHeader)(unsafe.Pointer(&s))
+	hdr.Len *= 4
+	hdr.Cap *= 4
+	return *(*[]byte)(unsafe.Pointer(&hdr))
+}
@@ -0,0 +1,75 @@
+package reftone
+
+import (
+	"math"
+	"testing"
+)
+
+func TestOscillatorPhaseContinuousAcrossFreqChange(t *testing.T) {
+	sampleRate := 48000.0
+	osc := NewOscillator(Sine, 440)
+	osc.envelope = 1  // skip the fade-in so raw samples are comparable
+	osc.envTarget = 1 // hold steady instead of ramping back toward muted
+
+	first := make([]float32, 100)
+	osc.Render(first, sampleRate)
+	phaseBefore := osc.phase
+
+	osc.SetFreq(220)
+	second := make([]float32, 2)
+	osc.Render(second, sampleRate)
+
+	// A restart would jump back to phase 0; phase continuity means the
+	// first sample after the change sits where the old frequency left
+	// off, and the next one is exactly one step of the *new* frequency
+	// further on.
+	wantPhase := phaseBefore + 220.0/sampleRate
+	if wantPhase >= 1 {
+		wantPhase -= math.Floor(wantPhase)
+	}
+	want := math.Sin(2 * math.Pi * wantPhase)
+	if math.Abs(float64(second[1])-want) > 1e-6 {
+		t.Fatalf("expected phase-continuous sample %.6f, got %.6f", want, second[1])
+	}
+}
+
+func TestOscillatorEnvelopeRampsUpGradually(t *testing.T) {
+	osc := NewOscillator(Sine, 440)
+	osc.SetMuted(false)
+
+	out := make([]float32, 10)
+	osc.Render(out, 48000)
+	if math.Abs(float64(out[len(out)-1])) >= 0.9 {
+		t.Fatalf("expected envelope still ramping after 10 samples, got %.4f", out[len(out)-1])
+	}
+
+	long := make([]float32, 2000) // well past the 15ms (720-sample) ramp at 48kHz
+	osc.Render(long, 48000)
+	if osc.envelope < 0.99 {
+		t.Fatalf("expected envelope to reach ~1 after a long render, got %.3f", osc.envelope)
+	}
+}
+
+func TestOscillatorMuteRampsDown(t *testing.T) {
+	osc := NewOscillator(Sine, 440)
+	osc.envelope = 1
+	osc.SetMuted(true)
+
+	long := make([]float32, 2000)
+	osc.Render(long, 48000)
+	if osc.envelope > 0.01 {
+		t.Fatalf("expected envelope to reach ~0 after muting, got %.3f", osc.envelope)
+	}
+}
+
+func TestWaveformSamplesBounded(t *testing.T) {
+	for _, w := range []Waveform{Sine, Triangle, SoftSquare} {
+		for i := 0; i < 100; i++ {
+			phase := float64(i) / 100
+			v := waveformSample(w, phase)
+			if v < -1.0001 || v > 1.0001 {
+				t.Fatalf("waveform %v sample out of range at phase %.2f: %.4f", w, phase, v)
+			}
+		}
+	}
+}
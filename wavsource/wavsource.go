@@ -0,0 +1,230 @@
+// Package wavsource decodes WAV files into mono float32 PCM data at a
+// caller-chosen sample rate, and slices the result into fixed-size
+// overlapping frames. It exists so offline pitch analysis over a recorded
+// take can reuse the same frame-at-a-time pipeline as live malgo capture,
+// without needing an audio device to test against.
+package wavsource
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"sync"
+	"time"
+)
+
+// Samples holds decoded mono PCM data resampled to SampleRate.
+type Samples struct {
+	SampleRate float64
+	Data       []float32
+}
+
+// Load reads a RIFF/WAVE file at path, flattens it to mono, and resamples
+// it to targetRate.
+func Load(path string, targetRate float64) (*Samples, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return Decode(f, targetRate)
+}
+
+// Decode parses WAV data from r, flattens it to mono, and resamples it to
+// targetRate. It supports 16-bit integer PCM and 32-bit float samples,
+// mono or stereo.
+func Decode(r io.Reader, targetRate float64) (*Samples, error) {
+	var riffHeader [12]byte
+	if _, err := io.ReadFull(r, riffHeader[:]); err != nil {
+		return nil, fmt.Errorf("read riff header: %w", err)
+	}
+	if string(riffHeader[0:4]) != "RIFF" || string(riffHeader[8:12]) != "WAVE" {
+		return nil, fmt.Errorf("not a RIFF/WAVE file")
+	}
+
+	var (
+		audioFormat   uint16
+		numChannels   uint16
+		sampleRate    uint32
+		bitsPerSample uint16
+		gotFmt        bool
+		pcm           []float32
+	)
+
+	for {
+		var chunkHeader [8]byte
+		if _, err := io.ReadFull(r, chunkHeader[:]); err != nil {
+			if err == io.EOF || err == io.ErrUnexpectedEOF {
+				break
+			}
+			return nil, fmt.Errorf("read chunk header: %w", err)
+		}
+		chunkID := string(chunkHeader[0:4])
+		chunkSize := binary.LittleEndian.Uint32(chunkHeader[4:8])
+
+		switch chunkID {
+		case "fmt ":
+			body := make([]byte, chunkSize)
+			if _, err := io.ReadFull(r, body); err != nil {
+				return nil, fmt.Errorf("read fmt chunk: %w", err)
+			}
+			audioFormat = binary.LittleEndian.Uint16(body[0:2])
+			numChannels = binary.LittleEndian.Uint16(body[2:4])
+			sampleRate = binary.LittleEndian.Uint32(body[4:8])
+			bitsPerSample = binary.LittleEndian.Uint16(body[14:16])
+			gotFmt = true
+		case "data":
+			if !gotFmt {
+				return nil, fmt.Errorf("data chunk before fmt chunk")
+			}
+			body := make([]byte, chunkSize)
+			if _, err := io.ReadFull(r, body); err != nil {
+				return nil, fmt.Errorf("read data chunk: %w", err)
+			}
+			decoded, err := decodeSamples(body, audioFormat, bitsPerSample)
+			if err != nil {
+				return nil, err
+			}
+			pcm = decoded
+		default:
+			if _, err := io.CopyN(io.Discard, r, int64(chunkSize)); err != nil && err != io.EOF {
+				return nil, fmt.Errorf("skip chunk %q: %w", chunkID, err)
+			}
+		}
+		if chunkSize%2 == 1 {
+			// Chunks are word-aligned; skip the pad byte.
+			if _, err := io.CopyN(io.Discard, r, 1); err != nil && err != io.EOF {
+				break
+			}
+		}
+	}
+
+	if !gotFmt || pcm == nil {
+		return nil, fmt.Errorf("missing fmt or data chunk")
+	}
+
+	mono := flattenToMono(pcm, int(numChannels))
+	return &Samples{
+		SampleRate: targetRate,
+		Data:       resample(mono, float64(sampleRate), targetRate),
+	}, nil
+}
+
+func decodeSamples(body []byte, audioFormat, bitsPerSample uint16) ([]float32, error) {
+	switch {
+	case audioFormat == 1 && bitsPerSample == 16:
+		out := make([]float32, len(body)/2)
+		for i := range out {
+			v := int16(binary.LittleEndian.Uint16(body[i*2 : i*2+2]))
+			out[i] = float32(v) / 32768.0
+		}
+		return out, nil
+	case audioFormat == 3 && bitsPerSample == 32:
+		out := make([]float32, len(body)/4)
+		for i := range out {
+			bits := binary.LittleEndian.Uint32(body[i*4 : i*4+4])
+			out[i] = math.Float32frombits(bits)
+		}
+		return out, nil
+	default:
+		return nil, fmt.Errorf("unsupported wav format %d/%d-bit", audioFormat, bitsPerSample)
+	}
+}
+
+func flattenToMono(samples []float32, channels int) []float32 {
+	if channels <= 1 {
+		return samples
+	}
+	frames := len(samples) / channels
+	out := make([]float32, frames)
+	for i := 0; i < frames; i++ {
+		var sum float32
+		for c := 0; c < channels; c++ {
+			sum += samples[i*channels+c]
+		}
+		out[i] = sum / float32(channels)
+	}
+	return out
+}
+
+// resample does linear-interpolated resampling from srcRate to dstRate.
+// It's adequate for feeding a pitch detector, which only needs the
+// waveform's shape preserved, not broadcast-quality resampling.
+func resample(samples []float32, srcRate, dstRate float64) []float32 {
+	if srcRate <= 0 || dstRate <= 0 || srcRate == dstRate || len(samples) == 0 {
+		return samples
+	}
+	ratio := srcRate / dstRate
+	outLen := int(float64(len(samples)) / ratio)
+	out := make([]float32, outLen)
+	for i := range out {
+		srcPos := float64(i) * ratio
+		i0 := int(srcPos)
+		if i0 >= len(samples)-1 {
+			out[i] = samples[len(samples)-1]
+			continue
+		}
+		frac := float32(srcPos - float64(i0))
+		out[i] = samples[i0] + frac*(samples[i0+1]-samples[i0])
+	}
+	return out
+}
+
+// FrameProducer slices decoded samples into fixed-size overlapping frames,
+// mirroring the cadence of the malgo capture callback so both input
+// sources can drive the same analysis loop. Its position is mutex-guarded
+// so a UI-driven Seek can reposition it while Next runs on the playback
+// goroutine.
+type FrameProducer struct {
+	mu        sync.Mutex
+	data      []float32
+	frameSize int
+	hopSize   int
+	pos       int
+}
+
+// NewFrameProducer returns a producer over data, yielding frameSize
+// samples per call advanced by hopSize samples each time.
+func NewFrameProducer(data []float32, frameSize, hopSize int) *FrameProducer {
+	return &FrameProducer{data: data, frameSize: frameSize, hopSize: hopSize}
+}
+
+// Next returns the next frame and its offset from the start of the
+// recording, or ok=false once fewer than frameSize samples remain.
+func (p *FrameProducer) Next(sampleRate float64) (frame []float32, offset time.Duration, ok bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.frameSize <= 0 || p.pos+p.frameSize > len(p.data) {
+		return nil, 0, false
+	}
+	frame = p.data[p.pos : p.pos+p.frameSize]
+	offset = time.Duration(float64(p.pos) / sampleRate * float64(time.Second))
+	p.pos += p.hopSize
+	return frame, offset, true
+}
+
+// Seek repositions the producer to offset from the start of the
+// recording, clamped to the available data, so a scrub control can jump
+// playback instead of only advancing hop by hop.
+func (p *FrameProducer) Seek(offset time.Duration, sampleRate float64) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	pos := int(offset.Seconds() * sampleRate)
+	if pos < 0 {
+		pos = 0
+	}
+	if pos > len(p.data) {
+		pos = len(p.data)
+	}
+	p.pos = pos
+}
+
+// Duration reports the total playback length of the decoded samples.
+func (s *Samples) Duration() time.Duration {
+	if s.SampleRate <= 0 {
+		return 0
+	}
+	return time.Duration(float64(len(s.Data)) / s.SampleRate * float64(time.Second))
+}
@@ -0,0 +1,139 @@
+package wavsource
+
+import (
+	"bytes"
+	"encoding/binary"
+	"math"
+	"testing"
+)
+
+// buildWAV assembles a minimal RIFF/WAVE buffer around raw PCM bytes so
+// tests don't need fixture files on disk.
+func buildWAV(channels int, sampleRate uint32, bitsPerSample uint16, audioFormat uint16, data []byte) []byte {
+	var buf bytes.Buffer
+	blockAlign := uint16(channels) * bitsPerSample / 8
+	byteRate := sampleRate * uint32(blockAlign)
+
+	buf.WriteString("RIFF")
+	binary.Write(&buf, binary.LittleEndian, uint32(36+len(data)))
+	buf.WriteString("WAVE")
+
+	buf.WriteString("fmt ")
+	binary.Write(&buf, binary.LittleEndian, uint32(16))
+	binary.Write(&buf, binary.LittleEndian, audioFormat)
+	binary.Write(&buf, binary.LittleEndian, uint16(channels))
+	binary.Write(&buf, binary.LittleEndian, sampleRate)
+	binary.Write(&buf, binary.LittleEndian, byteRate)
+	binary.Write(&buf, binary.LittleEndian, blockAlign)
+	binary.Write(&buf, binary.LittleEndian, bitsPerSample)
+
+	buf.WriteString("data")
+	binary.Write(&buf, binary.LittleEndian, uint32(len(data)))
+	buf.Write(data)
+
+	return buf.Bytes()
+}
+
+func TestDecodeMono16PCM(t *testing.T) {
+	var pcm bytes.Buffer
+	for i := 0; i < 100; i++ {
+		binary.Write(&pcm, binary.LittleEndian, int16(1000))
+	}
+	wav := buildWAV(1, 48000, 16, 1, pcm.Bytes())
+
+	samples, err := Decode(bytes.NewReader(wav), 48000)
+	if err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if len(samples.Data) != 100 {
+		t.Fatalf("expected 100 samples, got %d", len(samples.Data))
+	}
+	want := float32(1000) / 32768.0
+	if math.Abs(float64(samples.Data[0]-want)) > 1e-6 {
+		t.Fatalf("expected %.6f, got %.6f", want, samples.Data[0])
+	}
+}
+
+func TestDecodeStereoFlattensToMono(t *testing.T) {
+	var pcm bytes.Buffer
+	for i := 0; i < 10; i++ {
+		binary.Write(&pcm, binary.LittleEndian, float32(1.0))
+		binary.Write(&pcm, binary.LittleEndian, float32(-1.0))
+	}
+	wav := buildWAV(2, 44100, 32, 3, pcm.Bytes())
+
+	samples, err := Decode(bytes.NewReader(wav), 44100)
+	if err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if len(samples.Data) != 10 {
+		t.Fatalf("expected 10 mono frames, got %d", len(samples.Data))
+	}
+	for _, s := range samples.Data {
+		if math.Abs(float64(s)) > 1e-6 {
+			t.Fatalf("expected averaged channels near 0, got %.6f", s)
+		}
+	}
+}
+
+func TestDecodeResamples(t *testing.T) {
+	var pcm bytes.Buffer
+	for i := 0; i < 1000; i++ {
+		v := int16(10000 * math.Sin(2*math.Pi*100*float64(i)/8000))
+		binary.Write(&pcm, binary.LittleEndian, v)
+	}
+	wav := buildWAV(1, 8000, 16, 1, pcm.Bytes())
+
+	samples, err := Decode(bytes.NewReader(wav), 16000)
+	if err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if samples.SampleRate != 16000 {
+		t.Fatalf("expected target rate 16000, got %.0f", samples.SampleRate)
+	}
+	if len(samples.Data) < 1900 || len(samples.Data) > 2100 {
+		t.Fatalf("expected ~2000 resampled frames, got %d", len(samples.Data))
+	}
+}
+
+func TestDecodeRejectsBadHeader(t *testing.T) {
+	if _, err := Decode(bytes.NewReader([]byte("not a wav file at all")), 48000); err == nil {
+		t.Fatalf("expected error for non-RIFF input")
+	}
+}
+
+func TestFrameProducerOverlap(t *testing.T) {
+	data := make([]float32, 10)
+	for i := range data {
+		data[i] = float32(i)
+	}
+	p := NewFrameProducer(data, 4, 2)
+
+	frame, offset, ok := p.Next(1.0)
+	if !ok || offset != 0 {
+		t.Fatalf("expected first frame at offset 0, got %v ok=%v", offset, ok)
+	}
+	if frame[0] != 0 || frame[3] != 3 {
+		t.Fatalf("unexpected first frame: %v", frame)
+	}
+
+	frame, offset, ok = p.Next(1.0)
+	if !ok || offset.Seconds() != 2 {
+		t.Fatalf("expected second frame at offset 2s, got %v ok=%v", offset, ok)
+	}
+	if frame[0] != 2 || frame[3] != 5 {
+		t.Fatalf("unexpected second frame: %v", frame)
+	}
+
+	count := 2
+	for {
+		_, _, ok := p.Next(1.0)
+		if !ok {
+			break
+		}
+		count++
+	}
+	if count != 4 {
+		t.Fatalf("expected 4 total frames from 10 samples, got %d", count)
+	}
+}